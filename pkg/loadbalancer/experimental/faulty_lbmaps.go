@@ -0,0 +1,228 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package experimental
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+
+	"github.com/cilium/cilium/pkg/maps/lbmap"
+)
+
+// ErrInjectedFault is wrapped around every error that [FaultyLBMaps] injects.
+// Reconciler retry paths can assert on this with errors.Is to distinguish
+// injected failures from genuine BPF map errors.
+var ErrInjectedFault = errors.New("injected fault")
+
+// OpClass identifies a class of mutating [LBMaps] operation that
+// [FaultyLBMaps] can independently target with failures.
+type OpClass string
+
+const (
+	OpService       OpClass = "service"
+	OpBackend       OpClass = "backend"
+	OpRevNat        OpClass = "revnat"
+	OpAffinityMatch OpClass = "affinity-match"
+	OpSourceRange   OpClass = "source-range"
+)
+
+// FaultConfig configures the failures that [FaultyLBMaps] injects.
+type FaultConfig struct {
+	// Seed seeds the deterministic random source used to decide whether
+	// a given call fails. The same seed and the same sequence of calls
+	// always produce the same sequence of failures.
+	Seed int64
+
+	// Probability is the failure probability used for an [OpClass] that
+	// has no entry in [FaultConfig.ProbabilityByClass].
+	Probability float32
+
+	// ProbabilityByClass overrides [FaultConfig.Probability] for
+	// individual operation classes.
+	ProbabilityByClass map[OpClass]float32
+
+	// Allow, if non-empty, restricts fault injection to these classes.
+	// All other classes always succeed.
+	Allow []OpClass
+
+	// Deny excludes these classes from fault injection, even if they
+	// also appear in Allow.
+	Deny []OpClass
+}
+
+func (cfg FaultConfig) probabilityFor(op OpClass) float32 {
+	if len(cfg.Allow) > 0 && !slices.Contains(cfg.Allow, op) {
+		return 0
+	}
+	if slices.Contains(cfg.Deny, op) {
+		return 0
+	}
+	if p, ok := cfg.ProbabilityByClass[op]; ok {
+		return p
+	}
+	return cfg.Probability
+}
+
+// FaultTraceEntry records the outcome of a single op that passed through
+// [FaultyLBMaps], for tests to dump alongside [DumpLBMaps] output.
+type FaultTraceEntry struct {
+	Op       OpClass
+	Key      string
+	Injected bool
+}
+
+func (e FaultTraceEntry) String() string {
+	return fmt.Sprintf("TRACE: OP=%s KEY=%s INJECTED=%v", e.Op, e.Key, e.Injected)
+}
+
+// FaultyLBMaps wraps a [LBMaps] implementation and deterministically injects
+// failures into its mutating operations, so that reconciler retry and
+// convergence behavior can be exercised under test.
+//
+// Dump* operations are passed through unmodified, as they are used by tests
+// to observe the converged state of the wrapped maps rather than being part
+// of the reconciliation path itself.
+type FaultyLBMaps struct {
+	impl LBMaps
+	cfg  FaultConfig
+
+	mu    sync.Mutex
+	rng   *rand.Rand
+	trace []FaultTraceEntry
+}
+
+// NewFaultyLBMaps wraps maps with fault injection configured by cfg.
+func NewFaultyLBMaps(maps LBMaps, cfg FaultConfig) *FaultyLBMaps {
+	return &FaultyLBMaps{
+		impl: maps,
+		cfg:  cfg,
+		rng:  rand.New(rand.NewSource(cfg.Seed)),
+	}
+}
+
+// Trace returns the ops observed so far, in order.
+func (f *FaultyLBMaps) Trace() []FaultTraceEntry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FaultTraceEntry(nil), f.trace...)
+}
+
+// inject decides whether [op] on [key] should fail and records the decision
+// in the trace.
+func (f *FaultyLBMaps) inject(op OpClass, key fmt.Stringer) error {
+	p := f.cfg.probabilityFor(op)
+
+	f.mu.Lock()
+	injected := p > 0 && f.rng.Float32() < p
+	f.trace = append(f.trace, FaultTraceEntry{Op: op, Key: key.String(), Injected: injected})
+	f.mu.Unlock()
+
+	if injected {
+		return fmt.Errorf("%s %s: %w", op, key, ErrInjectedFault)
+	}
+	return nil
+}
+
+type stringerFunc func() string
+
+func (s stringerFunc) String() string { return s() }
+
+func keyString(key any) fmt.Stringer {
+	return stringerFunc(func() string { return fmt.Sprintf("%+v", key) })
+}
+
+func (f *FaultyLBMaps) UpdateService(key lbmap.ServiceKey, value lbmap.ServiceValue) error {
+	if err := f.inject(OpService, keyString(key)); err != nil {
+		return err
+	}
+	return f.impl.UpdateService(key, value)
+}
+
+func (f *FaultyLBMaps) DeleteService(key lbmap.ServiceKey) error {
+	if err := f.inject(OpService, keyString(key)); err != nil {
+		return err
+	}
+	return f.impl.DeleteService(key)
+}
+
+func (f *FaultyLBMaps) UpdateBackend(key lbmap.BackendKey, value lbmap.BackendValue) error {
+	if err := f.inject(OpBackend, keyString(key)); err != nil {
+		return err
+	}
+	return f.impl.UpdateBackend(key, value)
+}
+
+func (f *FaultyLBMaps) DeleteBackend(key lbmap.BackendKey) error {
+	if err := f.inject(OpBackend, keyString(key)); err != nil {
+		return err
+	}
+	return f.impl.DeleteBackend(key)
+}
+
+func (f *FaultyLBMaps) UpdateRevNat(key lbmap.RevNatKey, value lbmap.RevNatValue) error {
+	if err := f.inject(OpRevNat, keyString(key)); err != nil {
+		return err
+	}
+	return f.impl.UpdateRevNat(key, value)
+}
+
+func (f *FaultyLBMaps) DeleteRevNat(key lbmap.RevNatKey) error {
+	if err := f.inject(OpRevNat, keyString(key)); err != nil {
+		return err
+	}
+	return f.impl.DeleteRevNat(key)
+}
+
+func (f *FaultyLBMaps) UpdateAffinityMatch(key *lbmap.AffinityMatchKey, value *lbmap.AffinityMatchValue) error {
+	if err := f.inject(OpAffinityMatch, keyString(key)); err != nil {
+		return err
+	}
+	return f.impl.UpdateAffinityMatch(key, value)
+}
+
+func (f *FaultyLBMaps) DeleteAffinityMatch(key *lbmap.AffinityMatchKey) error {
+	if err := f.inject(OpAffinityMatch, keyString(key)); err != nil {
+		return err
+	}
+	return f.impl.DeleteAffinityMatch(key)
+}
+
+func (f *FaultyLBMaps) UpdateSourceRange(key lbmap.SourceRangeKey, value *lbmap.SourceRangeValue) error {
+	if err := f.inject(OpSourceRange, keyString(key)); err != nil {
+		return err
+	}
+	return f.impl.UpdateSourceRange(key, value)
+}
+
+func (f *FaultyLBMaps) DeleteSourceRange(key lbmap.SourceRangeKey) error {
+	if err := f.inject(OpSourceRange, keyString(key)); err != nil {
+		return err
+	}
+	return f.impl.DeleteSourceRange(key)
+}
+
+func (f *FaultyLBMaps) DumpService(cb func(lbmap.ServiceKey, lbmap.ServiceValue)) error {
+	return f.impl.DumpService(cb)
+}
+
+func (f *FaultyLBMaps) DumpBackend(cb func(lbmap.BackendKey, lbmap.BackendValue)) error {
+	return f.impl.DumpBackend(cb)
+}
+
+func (f *FaultyLBMaps) DumpRevNat(cb func(lbmap.RevNatKey, lbmap.RevNatValue)) error {
+	return f.impl.DumpRevNat(cb)
+}
+
+func (f *FaultyLBMaps) DumpAffinityMatch(cb func(*lbmap.AffinityMatchKey, *lbmap.AffinityMatchValue)) error {
+	return f.impl.DumpAffinityMatch(cb)
+}
+
+func (f *FaultyLBMaps) DumpSourceRange(cb func(lbmap.SourceRangeKey, *lbmap.SourceRangeValue)) error {
+	return f.impl.DumpSourceRange(cb)
+}
+
+var _ LBMaps = (*FaultyLBMaps)(nil)
@@ -0,0 +1,165 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Authors of Cilium
+
+package experimental
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/cilium/hive/hivetest"
+	"github.com/cilium/statedb"
+	"github.com/cilium/statedb/reconciler"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cilium/cilium/pkg/k8s"
+	"github.com/cilium/cilium/pkg/k8s/resource"
+	slim_corev1 "github.com/cilium/cilium/pkg/k8s/slim/k8s/api/core/v1"
+	"github.com/cilium/cilium/pkg/maps/lbmap"
+)
+
+// TestFaultyLBMapsTrace asserts the op class and injected flag recorded in
+// the trace that reconciler retry tests can assert against: a probability-1
+// config injects and traces a failure on every call, a probability-0
+// config traces every call as not injected, and Deny excludes an op class
+// from injection regardless of probability.
+func TestFaultyLBMapsTrace(t *testing.T) {
+	key := &lbmap.Service4Key{}
+	value := &lbmap.Service4Value{}
+
+	f := NewFaultyLBMaps(NewFakeLBMaps(), FaultConfig{Seed: 1, Probability: 0})
+	require.NoError(t, f.UpdateService(key, value))
+	trace := f.Trace()
+	require.Len(t, trace, 1)
+	require.Equal(t, OpService, trace[0].Op)
+	require.False(t, trace[0].Injected)
+
+	f = NewFaultyLBMaps(NewFakeLBMaps(), FaultConfig{Seed: 1, Probability: 1})
+	err := f.UpdateService(key, value)
+	require.ErrorIs(t, err, ErrInjectedFault)
+	trace = f.Trace()
+	require.Len(t, trace, 1)
+	require.True(t, trace[0].Injected)
+
+	f = NewFaultyLBMaps(NewFakeLBMaps(), FaultConfig{Seed: 1, Probability: 1, Deny: []OpClass{OpService}})
+	require.NoError(t, f.UpdateService(key, value))
+	trace = f.Trace()
+	require.Len(t, trace, 1)
+	require.False(t, trace[0].Injected)
+}
+
+// faultProbabilities are the failure probabilities every golden-file test
+// case under testdata/ is replayed with. 0 is always first and acts as the
+// fault-free baseline the other runs are compared against.
+var faultProbabilities = []float32{0, 0.05, 0.2}
+
+// waitForQuiescentFrontends waits until the frontend table has stopped
+// growing and every frontend currently in it has reconciled. A fixed
+// expected frontend count can't be derived from the service fixtures alone,
+// since a single Service can expand into a varying number of frontends
+// (ClusterIP, one per NodePort address, LoadBalancer, ...), so instead this
+// polls until two consecutive, fully-reconciled observations agree on the
+// frontend count.
+func waitForQuiescentFrontends(t *testing.T, db *statedb.DB, writer *Writer, timeout time.Duration) {
+	t.Helper()
+
+	lastCount := -1
+	require.Eventually(t, func() bool {
+		txn := db.ReadTxn()
+		count := 0
+		allDone := true
+		for fe := range writer.Frontends().All(txn) {
+			count++
+			if fe.Status.Kind != reconciler.StatusKindDone {
+				allDone = false
+			}
+		}
+		if count == 0 || !allDone {
+			lastCount = -1
+			return false
+		}
+		stable := count == lastCount
+		lastCount = count
+		return stable
+	}, timeout, 10*time.Millisecond, "reconciler did not converge to a stable set of frontends")
+}
+
+// TestGoldenCasesWithFaultInjection replays every golden-file test case
+// found under testdata/ with fault injection enabled at increasing
+// probabilities, and checks that the reconciler still converges to the same
+// final state as the fault-free run (just slower, via retries). This
+// exercises reconciler retry paths against the full range of LBMaps
+// operations instead of a synthetic failure.
+func TestGoldenCasesWithFaultInjection(t *testing.T) {
+	const testDataDir = "testdata"
+	cases, err := os.ReadDir(testDataDir)
+	if err != nil {
+		t.Skipf("no golden-file test cases under %s: %s", testDataDir, err)
+	}
+
+	for _, c := range cases {
+		if !c.IsDir() {
+			continue
+		}
+		caseDir := path.Join(testDataDir, c.Name())
+
+		svcs := readObjects[*slim_corev1.Service](t, caseDir, "svc")
+		epSlices := readObjects[*k8s.Endpoints](t, caseDir, "eps")
+		if len(svcs) == 0 {
+			// Not a case directory (e.g. shared fixtures), skip.
+			continue
+		}
+
+		t.Run(c.Name(), func(t *testing.T) {
+			var baseline []MapDump
+
+			for _, p := range faultProbabilities {
+				t.Run(fmt.Sprintf("p=%.2f", p), func(t *testing.T) {
+					maps := NewFakeLBMaps()
+
+					services := make(chan resource.Event[*slim_corev1.Service], len(svcs)+1)
+					pods := make(chan resource.Event[*slim_corev1.Pod], 1)
+					endpoints := make(chan resource.Event[*k8s.Endpoints], len(epSlices)+1)
+
+					var (
+						writer *Writer
+						db     *statedb.DB
+						bo     *BPFOps
+					)
+					h := TestHive(maps, services, pods, endpoints, p, &writer, &db, &bo)
+
+					log := hivetest.Logger(t)
+					require.NoError(t, h.Start(log, context.Background()), "hive.Start")
+					defer func() {
+						require.NoError(t, h.Stop(log, context.Background()), "hive.Stop")
+					}()
+
+					for _, svc := range svcs {
+						services <- UpsertEvent(svc)
+					}
+					for _, eps := range epSlices {
+						endpoints <- UpsertEvent(eps)
+					}
+
+					waitForQuiescentFrontends(t, db, writer, 10*time.Second)
+
+					actual := DumpLBMaps(maps, frontendAddrs[0], true, nil)
+					if p == 0 {
+						baseline = actual
+						return
+					}
+					// Fault injection causes operations to be retried, so the
+					// raw IDs can differ from the fault-free baseline; the
+					// dump renumbers them by address and is sorted, so runs
+					// that converged to the same entries compare equal.
+					require.ElementsMatch(t, baseline, actual,
+						"reconciled state at failure probability %.2f diverged from the fault-free baseline", p)
+				})
+			}
+		})
+	}
+}
@@ -144,19 +144,75 @@ func DeleteEvent[Obj k8sRuntime.Object](obj Obj) resource.Event[Obj] {
 	}
 }
 
-type numeric interface {
-	~int | ~uint32 | ~uint16
+// idCanonicalizer assigns small, deterministic canonical identifiers to the
+// raw IDs handed out by the service/backend ID allocators. IDs are tracked
+// by their int64 value so the canonicalizer works regardless of the
+// underlying ID type (uint16 revnat IDs, uint32 backend IDs, ...).
+//
+// With fault injection enabled, mutating operations are retried and thus the
+// numeric ID a given address ends up with depends on the order retries
+// happen to succeed in. The address an ID is associated with is not
+// affected by retries, so sorting the observed IDs by their address and
+// renumbering them from there gives a stable, reproducible ordering that
+// golden files can rely on while still preserving the cross-references
+// between, e.g., a SVC's BEID and a BE's ID.
+type idCanonicalizer struct {
+	addrOf map[int64]string
+	canon  map[int64]int64
 }
 
-// TODO: Figure out what to do about the IDs. If we want to do fault inject the
-// operations will be retried and the ID allocations are non-deterministic.
-func sanitizeID[Num numeric](n Num, sanitize bool) string {
+func newIDCanonicalizer() *idCanonicalizer {
+	return &idCanonicalizer{addrOf: map[int64]string{}}
+}
+
+// observe records that [id] is associated with [addr]. The first address
+// seen for a given ID wins; subsequent observations (e.g. for the other
+// backend slots of the same service) are ignored.
+//
+// addr must uniquely identify the entry the ID was allocated for (i.e.
+// include every dimension the underlying map key varies by, such as scope
+// or protocol) -- two distinct entries observed under the same addr make
+// the sort in [idCanonicalizer.finalize] order them arbitrarily, since map
+// iteration order is randomized and sort.Slice is not stable.
+func (c *idCanonicalizer) observe(id int64, addr string) {
+	if id == 0 {
+		return
+	}
+	if _, ok := c.addrOf[id]; !ok {
+		c.addrOf[id] = addr
+	}
+}
+
+// finalize must be called once all IDs have been observed. It assigns
+// canonical IDs in address order. This relies on every observed address
+// being unique (see [idCanonicalizer.observe]); otherwise the relative order
+// of IDs sharing an address is unspecified.
+func (c *idCanonicalizer) finalize() {
+	ids := make([]int64, 0, len(c.addrOf))
+	for id := range c.addrOf {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return c.addrOf[ids[i]] < c.addrOf[ids[j]]
+	})
+	c.canon = make(map[int64]int64, len(ids))
+	for i, id := range ids {
+		c.canon[id] = int64(i + 1)
+	}
+}
+
+// format renders [id] either as its raw numeric value, or (when sanitize is
+// true) as its canonical, address-ordered form.
+func (c *idCanonicalizer) format(id int64, sanitize bool) string {
 	if !sanitize {
-		return strconv.FormatInt(int64(n), 10)
+		return strconv.FormatInt(id, 10)
 	}
-	if n == 0 {
+	if id == 0 {
 		return "<zero>"
 	}
+	if canon, ok := c.canon[id]; ok {
+		return fmt.Sprintf("<canon-%d>", canon)
+	}
 	return "<non-zero>"
 }
 
@@ -189,6 +245,37 @@ type MapDump = string
 func DumpLBMaps(lbmaps LBMaps, feAddr loadbalancer.L3n4Addr, sanitizeIDs bool, customIPString func(net.IP) string) (out []MapDump) {
 	out = []string{}
 
+	// First pass: observe which address each service (revnat) and backend
+	// ID belongs to, so IDs can be renumbered in a stable, address-derived
+	// order regardless of the order the underlying allocator handed them
+	// out in (which, under fault injection and retries, is non-deterministic).
+	svcIDs := newIDCanonicalizer()
+	beIDs := newIDCanonicalizer()
+	if sanitizeIDs {
+		if err := lbmaps.DumpService(func(svcKey lbmap.ServiceKey, svcValue lbmap.ServiceValue) {
+			svcKey = svcKey.ToHost()
+			svcValue = svcValue.ToHost()
+			// The key must uniquely identify the entry being canonicalized:
+			// address and port alone collide for, e.g., an internal-scope
+			// frontend and an external-scope frontend at the same
+			// address:port, or a TCP and a UDP frontend at the same
+			// address:port.
+			svcIDs.observe(int64(svcValue.GetRevNat()), fmt.Sprintf("%s:%d/%d/%d",
+				svcKey.GetAddress(), svcKey.GetPort(), svcKey.GetProtocol(), svcKey.GetScope()))
+		}); err != nil {
+			panic(err)
+		}
+		if err := lbmaps.DumpBackend(func(beKey lbmap.BackendKey, beValue lbmap.BackendValue) {
+			beValue = beValue.ToHost()
+			beIDs.observe(int64(beKey.GetID()), fmt.Sprintf("%s:%d/%d",
+				beValue.GetAddress(), beValue.GetPort(), beValue.GetProtocol()))
+		}); err != nil {
+			panic(err)
+		}
+		svcIDs.finalize()
+		beIDs.finalize()
+	}
+
 	replaceAddr := func(addr net.IP, port uint16) (s string) {
 		if addr.IsUnspecified() {
 			s = "<zero>"
@@ -224,10 +311,10 @@ func DumpLBMaps(lbmaps LBMaps, feAddr loadbalancer.L3n4Addr, sanitizeIDs bool, c
 			addrS += "/i"
 		}
 		out = append(out, fmt.Sprintf("SVC: ID=%s ADDR=%s SLOT=%d BEID=%s COUNT=%d QCOUNT=%d FLAGS=%s",
-			sanitizeID(svcValue.GetRevNat(), sanitizeIDs),
+			svcIDs.format(int64(svcValue.GetRevNat()), sanitizeIDs),
 			addrS,
 			svcKey.GetBackendSlot(),
-			sanitizeID(svcValue.GetBackendID(), sanitizeIDs),
+			beIDs.format(int64(svcValue.GetBackendID()), sanitizeIDs),
 			svcValue.GetCount(),
 			svcValue.GetQCount(),
 			strings.ReplaceAll(
@@ -245,7 +332,7 @@ func DumpLBMaps(lbmaps LBMaps, feAddr loadbalancer.L3n4Addr, sanitizeIDs bool, c
 		addrS := replaceAddr(addr, beValue.GetPort())
 		stateS, _ := loadbalancer.GetBackendStateFromFlags(beValue.GetFlags()).String()
 		out = append(out, fmt.Sprintf("BE: ID=%s ADDR=%s STATE=%s",
-			sanitizeID(beKey.GetID(), sanitizeIDs),
+			beIDs.format(int64(beKey.GetID()), sanitizeIDs),
 			addrS,
 			stateS,
 		))
@@ -269,7 +356,7 @@ func DumpLBMaps(lbmaps LBMaps, feAddr loadbalancer.L3n4Addr, sanitizeIDs bool, c
 		}
 
 		out = append(out, fmt.Sprintf("REV: ID=%s ADDR=%s",
-			sanitizeID(revKey.GetKey(), sanitizeIDs),
+			svcIDs.format(int64(revKey.GetKey()), sanitizeIDs),
 			addr,
 		))
 	}
@@ -279,9 +366,9 @@ func DumpLBMaps(lbmaps LBMaps, feAddr loadbalancer.L3n4Addr, sanitizeIDs bool, c
 
 	affCB := func(affKey *lbmap.AffinityMatchKey, _ *lbmap.AffinityMatchValue) {
 		affKey = affKey.ToHost()
-		out = append(out, fmt.Sprintf("AFF: ID=%s BEID=%d",
-			sanitizeID(affKey.RevNATID, sanitizeIDs),
-			affKey.BackendID,
+		out = append(out, fmt.Sprintf("AFF: ID=%s BEID=%s",
+			svcIDs.format(int64(affKey.RevNATID), sanitizeIDs),
+			beIDs.format(int64(affKey.BackendID), sanitizeIDs),
 		))
 	}
 
@@ -292,7 +379,7 @@ func DumpLBMaps(lbmaps LBMaps, feAddr loadbalancer.L3n4Addr, sanitizeIDs bool, c
 	srcRangeCB := func(key lbmap.SourceRangeKey, _ *lbmap.SourceRangeValue) {
 		key = key.ToHost()
 		out = append(out, fmt.Sprintf("SRCRANGE: ID=%s CIDR=%s",
-			sanitizeID(key.GetRevNATID(), sanitizeIDs),
+			svcIDs.format(int64(key.GetRevNATID()), sanitizeIDs),
 			key.GetCIDR(),
 		))
 	}
@@ -464,7 +551,40 @@ func FastCheckEmptyTables(db *statedb.DB, writer *Writer, bo *BPFOps) bool {
 	return true
 }
 
-func checkTablesAndMaps(db *statedb.DB, writer *Writer, maps LBMaps, expectedTablesF func() ([]byte, error), expectedMapsF func() ([]byte, error), writeData func(string, []byte, fs.FileMode), customIPString func(net.IP) string) bool {
+// GoldenMode controls how checkTablesAndMaps reacts to a mismatch between
+// the actual and expected golden files, selected via the CILIUM_LB_GOLDEN
+// environment variable.
+type GoldenMode int
+
+const (
+	// GoldenCheck compares against the expected files and reports any
+	// mismatch as a test failure. The default.
+	GoldenCheck GoldenMode = iota
+	// GoldenUpdate always overwrites the expected files with the actual
+	// output, regardless of whether they matched.
+	GoldenUpdate
+	// GoldenUpdateOnMismatch overwrites the expected files only when they
+	// don't match the actual output, and otherwise behaves like GoldenCheck.
+	GoldenUpdateOnMismatch
+)
+
+// goldenMode is read once from CILIUM_LB_GOLDEN, e.g.
+// CILIUM_LB_GOLDEN=update go test ./pkg/loadbalancer/experimental/...
+// regenerates expected.tables and expected.maps for every golden case run.
+var goldenMode = func() GoldenMode {
+	switch strings.ToLower(os.Getenv("CILIUM_LB_GOLDEN")) {
+	case "update":
+		return GoldenUpdate
+	case "update-on-mismatch":
+		return GoldenUpdateOnMismatch
+	default:
+		return GoldenCheck
+	}
+}()
+
+func checkTablesAndMaps(t *testing.T, db *statedb.DB, writer *Writer, maps LBMaps, expectedTablesF func() ([]byte, error), expectedMapsF func() ([]byte, error), writeData func(string, []byte, fs.FileMode), customIPString func(net.IP) string) bool {
+	t.Helper()
+
 	allDone := true
 	count := 0
 	for fe := range writer.Frontends().All(db.ReadTxn()) {
@@ -479,29 +599,145 @@ func checkTablesAndMaps(db *statedb.DB, writer *Writer, maps LBMaps, expectedTab
 
 	var tableBuf bytes.Buffer
 	writer.DebugDump(db.ReadTxn(), &tableBuf)
-	actualTables := tableBuf.Bytes()
+	actualTables := sanitizeTables(tableBuf.Bytes())
+	writeData("actual.tables", actualTables, 0644)
 
 	var expectedTables []byte
 	if expectedData, err := expectedTablesF(); err == nil {
-		expectedTables = expectedData
+		expectedTables = sanitizeTables(expectedData)
 	}
-	actualTables = sanitizeTables(actualTables)
-	expectedTables = sanitizeTables(expectedTables)
 
-	writeData("actual.tables", actualTables, 0644)
+	actualMaps := DumpLBMaps(maps, frontendAddrs[0], true, customIPString)
+	sort.Strings(actualMaps)
+	writeData("actual.maps", []byte(strings.Join(actualMaps, "\n")+"\n"), 0644)
 
 	var expectedMaps []MapDump
 	if expectedData, err := expectedMapsF(); err == nil {
 		expectedMaps = strings.Split(strings.TrimSpace(string(expectedData)), "\n")
+		sort.Strings(expectedMaps)
+	}
+
+	tablesMatch := bytes.Equal(actualTables, expectedTables)
+	mapsMatch := slices.Equal(expectedMaps, actualMaps)
+	match := tablesMatch && mapsMatch
+
+	switch goldenMode {
+	case GoldenUpdate:
+		writeData("expected.tables", actualTables, 0644)
+		writeData("expected.maps", []byte(strings.Join(actualMaps, "\n")+"\n"), 0644)
+		return true
+	case GoldenUpdateOnMismatch:
+		if !match {
+			t.Logf("CILIUM_LB_GOLDEN=update-on-mismatch: regenerating expected.tables and expected.maps")
+			writeData("expected.tables", actualTables, 0644)
+			writeData("expected.maps", []byte(strings.Join(actualMaps, "\n")+"\n"), 0644)
+			return true
+		}
 	}
-	actualMaps := DumpLBMaps(maps, frontendAddrs[0], true, customIPString)
 
-	writeData(
-		"actual.maps",
-		[]byte(strings.Join(actualMaps, "\n")+"\n"),
-		0644,
+	if !match {
+		reportGoldenDiff(t, tablesMatch, expectedTables, actualTables, expectedMaps, actualMaps)
+	}
+	return match
+}
+
+// mapDumpPrefixes are the distinct sections that make up a [MapDump] slice,
+// in the order they're reported in.
+var mapDumpPrefixes = []string{"SVC:", "BE:", "REV:", "AFF:", "SRCRANGE:"}
+
+// reportGoldenDiff surfaces a checkTablesAndMaps mismatch through t,
+// reporting the tables and each BPF map category separately so that a
+// failure doesn't require eyeballing one large unified blob to find what
+// changed.
+func reportGoldenDiff(t *testing.T, tablesMatch bool, expectedTables, actualTables []byte, expectedMaps, actualMaps []MapDump) {
+	t.Helper()
+
+	if !tablesMatch {
+		t.Errorf("tables mismatch:\n%s", colorizeDiff(unifiedDiffString("expected.tables", "actual.tables", string(expectedTables), string(actualTables))))
+	}
+
+	if !slices.Equal(expectedMaps, actualMaps) {
+		for _, prefix := range mapDumpPrefixes {
+			expectedSection := filterMapDumpPrefix(expectedMaps, prefix)
+			actualSection := filterMapDumpPrefix(actualMaps, prefix)
+			if slices.Equal(expectedSection, actualSection) {
+				continue
+			}
+			t.Errorf("%s %d line(s) differ:\n%s",
+				prefix,
+				countDifferingLines(expectedSection, actualSection),
+				colorizeDiff(unifiedDiffString(
+					"expected "+prefix, "actual "+prefix,
+					strings.Join(expectedSection, "\n"), strings.Join(actualSection, "\n"),
+				)),
+			)
+		}
+	}
+}
+
+func filterMapDumpPrefix(dump []MapDump, prefix string) (out []MapDump) {
+	for _, line := range dump {
+		if strings.HasPrefix(line, prefix) {
+			out = append(out, line)
+		}
+	}
+	return
+}
+
+// countDifferingLines returns the number of lines whose multiplicity
+// differs between expected and actual, counting duplicate lines separately
+// rather than collapsing them.
+func countDifferingLines(expected, actual []MapDump) int {
+	counts := make(map[string]int, len(expected)+len(actual))
+	for _, line := range expected {
+		counts[line]++
+	}
+	for _, line := range actual {
+		counts[line]--
+	}
+	count := 0
+	for _, n := range counts {
+		if n < 0 {
+			n = -n
+		}
+		count += n
+	}
+	return count
+}
+
+func unifiedDiffString(fromFile, toFile, a, b string) string {
+	diff := difflib.UnifiedDiff{
+		A:        difflib.SplitLines(a),
+		B:        difflib.SplitLines(b),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  2,
+	}
+	text, _ := difflib.GetUnifiedDiffString(diff)
+	return text
+}
+
+// colorizeDiff highlights added/removed lines of a unified diff for easier
+// scanning in a terminal. Lines that aren't part of the +/- hunk body (file
+// headers, hunk markers) are left untouched.
+func colorizeDiff(diff string) string {
+	const (
+		red   = "\x1b[31m"
+		green = "\x1b[32m"
+		reset = "\x1b[0m"
 	)
-	return bytes.Equal(actualTables, expectedTables) && slices.Equal(expectedMaps, actualMaps)
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---"):
+			// File header, not a content line.
+		case strings.HasPrefix(line, "+"):
+			lines[i] = green + line + reset
+		case strings.HasPrefix(line, "-"):
+			lines[i] = red + line + reset
+		}
+	}
+	return strings.Join(lines, "\n")
 }
 
 func logDiff(t *testing.T, fileA, fileB string) {
@@ -511,15 +747,7 @@ func logDiff(t *testing.T, fileA, fileB string) {
 	require.NoError(t, err)
 	contentsB, _ := os.ReadFile(fileB)
 
-	diff := difflib.UnifiedDiff{
-		A:        difflib.SplitLines(string(contentsA)),
-		B:        difflib.SplitLines(string(contentsB)),
-		FromFile: fileA,
-		ToFile:   fileB,
-		Context:  2,
-	}
-	text, _ := difflib.GetUnifiedDiffString(diff)
-	if len(text) > 0 {
+	if text := unifiedDiffString(fileA, fileB, string(contentsA), string(contentsB)); len(text) > 0 {
 		t.Logf("\n%s", text)
 	}
 }
@@ -569,12 +797,13 @@ func TestHive(maps LBMaps,
 					if rm, ok := maps.(*BPFLBMaps); ok {
 						lc.Append(rm)
 					}
-					return maps
-					/*
-						return &FaultyLBMaps{
-							impl:               maps,
-							failureProbability: failureProbability,
-						}*/
+					if failureProbability <= 0 {
+						return maps
+					}
+					return NewFaultyLBMaps(maps, FaultConfig{
+						Seed:        1,
+						Probability: failureProbability,
+					})
 				},
 			),
 